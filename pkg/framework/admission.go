@@ -0,0 +1,33 @@
+package framework
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// runAdmission runs each AdmissionFunc in order, threading the (possibly
+// mutated) object through the chain, the way a chain of mutating admission
+// webhooks would.
+func runAdmission(ctx context.Context, hooks []AdmissionFunc, namespace, name string, obj runtime.Object) (runtime.Object, error) {
+	for _, hook := range hooks {
+		mutated, err := hook(ctx, namespace, name, obj)
+		if err != nil {
+			return nil, &AdmissionError{Err: err}
+		}
+		obj = mutated
+	}
+
+	return obj, nil
+}
+
+// AdmissionError distinguishes a hook's rejection from any other failure a
+// caller threading runAdmission's result through a retry loop (e.g.
+// GuaranteedUpdate) might otherwise mistake for a storage error.
+type AdmissionError struct {
+	Err error
+}
+
+func (e *AdmissionError) Error() string { return e.Err.Error() }
+
+func (e *AdmissionError) Unwrap() error { return e.Err }
@@ -5,29 +5,17 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-type watchEvent struct {
-	Type   watch.EventType `json:"type"`
-	Object any             `json:"object"`
-}
-
 type ServerConfig struct {
 	KubeClient        client.Client
 	DynamicKubeClient *dynamic.DynamicClient
@@ -37,6 +25,10 @@ type ServerConfig struct {
 	Group             string
 	Version           string
 	APIKinds          []APIKind
+
+	// AuditSink, if set, receives a structured AuditEvent for every stage of
+	// every request. Leave nil to disable audit logging.
+	AuditSink AuditSink
 }
 
 type APIKind struct {
@@ -44,14 +36,50 @@ type APIKind struct {
 	RawEndpoints    map[string]http.HandlerFunc
 	Resources       []Resource
 	CustomResources []CustomResource
+
+	// TableConvertor is used for any Resource of this APIKind that doesn't
+	// set its own.
+	TableConvertor TableConvertor
+
+	// OpenAPIDefinitions publishes this kind's schema in the /openapi/v2 and
+	// /openapi/v3 discovery documents. Leave unset to publish no schema for
+	// this kind.
+	OpenAPIDefinitions OpenAPIDefinitions
 }
 type Resource struct {
 	CreateNew     ResourceCreateNew
 	CreateNewList ResourceCreateNewList
-	ListCallback  ResourceListCallback
-	WatchCallback ResourceWatchCallback
+
+	ListCallback   ResourceListCallback
+	WatchCallback  ResourceWatchCallback
+	CreateCallback ResourceCreateCallback
+	UpdateCallback ResourceUpdateCallback
+	PatchCallback  ResourcePatchCallback
+	DeleteCallback ResourceDeleteCallback
+
+	// MutatingAdmission runs, in order, on the decoded object before it is
+	// persisted by Create, Update or Patch, analogous to a chain of
+	// apiserver mutating admission webhooks. An error aborts the request.
+	MutatingAdmission []AdmissionFunc
+
+	// FieldIndexers declares additional fields (beyond the built-in
+	// metadata.name/metadata.namespace) this Resource's objects can be
+	// filtered on via fieldSelector, the way controller-runtime's
+	// cache.Indexer exposes fields for List/Watch. Selectors are evaluated
+	// in the framework, so the upstream store never needs to understand
+	// them.
+	FieldIndexers map[string]FieldIndexerFunc
+
+	// TableConvertor enables server-side Table output (kubectl get) for this
+	// Resource. If left unset, the owning APIKind's TableConvertor is used
+	// instead.
+	TableConvertor TableConvertor
 }
 
+// FieldIndexerFunc extracts the value of one selectable field from obj, for
+// evaluating fieldSelector against a Resource's FieldIndexers.
+type FieldIndexerFunc func(client.Object) string
+
 type ResourceCreateNew func() (schema.GroupVersionResource, client.Object)
 
 type ResourceCreateNewList func() (schema.GroupVersionResource, client.ObjectList)
@@ -60,6 +88,18 @@ type ResourceListCallback func(context.Context, string, string, client.ObjectLis
 
 type ResourceWatchCallback func(context.Context, string, string, *unstructured.Unstructured) (any, error)
 
+type ResourceCreateCallback func(context.Context, string, string, client.Object) (any, error)
+
+type ResourceUpdateCallback func(context.Context, string, string, client.Object) (any, error)
+
+type ResourcePatchCallback func(context.Context, string, string, client.Object) (any, error)
+
+type ResourceDeleteCallback func(context.Context, string, string, *metav1.DeleteOptions) (any, error)
+
+// AdmissionFunc mutates or validates obj before it is persisted by Create,
+// Update or Patch. Returning an error aborts the request.
+type AdmissionFunc func(ctx context.Context, namespace, name string, obj runtime.Object) (runtime.Object, error)
+
 type CustomResource struct {
 	CreateHandler  CustomResourceHandlerFunc
 	GetHandler     CustomResourceHandlerFunc
@@ -75,10 +115,97 @@ type Server struct {
 	KubeClient         client.Client
 	DynamicKubeCluient *dynamic.DynamicClient
 
-	port     string
-	certFile string
-	keyFile  string
-	mux      *http.ServeMux
+	port      string
+	certFile  string
+	keyFile   string
+	mux       *http.ServeMux
+	auditSink AuditSink
+}
+
+// resourceRequest bundles a Resource's callbacks with the addressing of a
+// single incoming request and dispatches it to the per-verb handler, the way
+// the apiserver separates get.go/list.go/create.go/update.go/patch.go/delete.go.
+type resourceRequest struct {
+	srv            *Server
+	res            Resource
+	tableConvertor TableConvertor
+	namespace      string
+	name           string
+}
+
+func (rr *resourceRequest) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if strings.EqualFold(r.URL.Query().Get("watch"), "true") {
+			rr.handleWatch(w, r)
+			return
+		}
+
+		if rr.name != "" {
+			rr.handleGet(w, r)
+			return
+		}
+
+		rr.handleList(w, r)
+	case http.MethodPost:
+		rr.handleCreate(w, r)
+	case http.MethodPut:
+		rr.handleUpdate(w, r)
+	case http.MethodPatch:
+		rr.handlePatch(w, r)
+	case http.MethodDelete:
+		if rr.name == "" {
+			rr.handleDeleteCollection(w, r)
+			return
+		}
+
+		rr.handleDelete(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// completeAPIResource fills in discovery metadata an APIKind left unset,
+// the way the apiserver derives it from a resource's registered storage
+// rather than requiring every caller to spell it out.
+func completeAPIResource(ak APIKind) metav1.APIResource {
+	res := ak.ApiResource
+
+	if res.SingularName == "" {
+		res.SingularName = strings.ToLower(res.Kind)
+	}
+
+	if len(res.Verbs) == 0 {
+		if len(ak.Resources) > 0 {
+			res.Verbs = metav1.Verbs{"get", "list", "watch", "create", "update", "patch", "delete", "deletecollection"}
+		} else {
+			var verbs metav1.Verbs
+			for _, cr := range ak.CustomResources {
+				if cr.GetHandler != nil {
+					verbs = append(verbs, "get")
+				}
+				if cr.ListHandler != nil {
+					verbs = append(verbs, "list")
+				}
+				if cr.WatchHandler != nil {
+					verbs = append(verbs, "watch")
+				}
+				if cr.CreateHandler != nil {
+					verbs = append(verbs, "create")
+				}
+				if cr.ReplaceHandler != nil {
+					verbs = append(verbs, "update")
+				}
+				if cr.DeleteHandler != nil {
+					verbs = append(verbs, "delete")
+				}
+				break
+			}
+			res.Verbs = verbs
+		}
+	}
+
+	return res
 }
 
 func NewServer(config ServerConfig) *Server {
@@ -132,7 +259,7 @@ func NewServer(config ServerConfig) *Server {
 		}
 		existingApiResources[ak.ApiResource.Name] = true
 
-		apiResources = append(apiResources, ak.ApiResource)
+		apiResources = append(apiResources, completeAPIResource(ak))
 
 		for ep, fn := range ak.RawEndpoints {
 			srv.mux.HandleFunc("/apis/"+config.Group+"/"+config.Version+"/"+ak.ApiResource.Name+ep, fn)
@@ -141,17 +268,14 @@ func NewServer(config ServerConfig) *Server {
 		for ii := range ak.Resources {
 			res := ak.Resources[ii]
 
+			tableConvertor := res.TableConvertor
+			if tableConvertor.TableRow == nil {
+				tableConvertor = ak.TableConvertor
+			}
+
 			srv.mux.HandleFunc("/apis/"+config.Group+"/"+config.Version+"/"+ak.ApiResource.Name, func(w http.ResponseWriter, r *http.Request) {
-				srv.handleResourceFunc(
-					res.CreateNew,
-					res.CreateNewList,
-					res.ListCallback,
-					res.WatchCallback,
-					"",
-					"",
-					w,
-					r,
-				)
+				rr := &resourceRequest{srv: &srv, res: res, tableConvertor: tableConvertor}
+				rr.serveHTTP(w, r)
 			})
 
 			if !ak.ApiResource.Namespaced {
@@ -162,29 +286,13 @@ func NewServer(config ServerConfig) *Server {
 						return
 					}
 
-					srv.handleResourceFunc(
-						res.CreateNew,
-						res.CreateNewList,
-						res.ListCallback,
-						res.WatchCallback,
-						"",
-						parts[0],
-						w,
-						r,
-					)
+					rr := &resourceRequest{srv: &srv, res: res, tableConvertor: tableConvertor, name: parts[0]}
+					rr.serveHTTP(w, r)
 				})
 			} else {
 				resourceHandlersNamespaced[ak.ApiResource.Name] = func(namespace string, name string, w http.ResponseWriter, r *http.Request) {
-					srv.handleResourceFunc(
-						res.CreateNew,
-						res.CreateNewList,
-						res.ListCallback,
-						res.WatchCallback,
-						namespace,
-						name,
-						w,
-						r,
-					)
+					rr := &resourceRequest{srv: &srv, res: res, tableConvertor: tableConvertor, namespace: namespace, name: name}
+					rr.serveHTTP(w, r)
 				}
 			}
 		}
@@ -301,13 +409,22 @@ func NewServer(config ServerConfig) *Server {
 		})
 	}
 
+	registerOpenAPIHandlers(&srv, config)
+	registerMetricsHandler(&srv)
+
+	srv.auditSink = config.AuditSink
+
 	return &srv
 }
 
 func (s *Server) Start(ctx context.Context) (err error) {
+	handler := metricsMiddleware(s.mux)
+	handler = auditMiddleware(s.auditSink)(handler)
+	handler = gzipMiddleware(handler)
+
 	srv := http.Server{
 		Addr:      s.port,
-		Handler:   s.mux,
+		Handler:   handler,
 		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
 	}
 
@@ -325,279 +442,3 @@ func (s *Server) Start(ctx context.Context) (err error) {
 
 	return nil
 }
-
-func (s *Server) handleResourceFunc(
-	createNew ResourceCreateNew,
-	createNewList ResourceCreateNewList,
-	listCallback ResourceListCallback,
-	watchCallback ResourceWatchCallback,
-	namespace,
-	name string,
-	w http.ResponseWriter,
-	r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "only GET", http.StatusMethodNotAllowed)
-		return
-	}
-
-	newGVR, _ := createNew()
-	newKind, err := s.KubeClient.RESTMapper().KindFor(newGVR)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to find kind: %s", err.Error()), http.StatusInternalServerError)
-		return
-	}
-
-	newListGVR, emptyList := createNewList()
-	newKindList, err := s.KubeClient.RESTMapper().KindFor(newListGVR)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to find list kind: %s", err.Error()), http.StatusInternalServerError)
-		return
-	}
-	emptyList.GetObjectKind().SetGroupVersionKind(newKindList)
-
-	if strings.EqualFold(r.URL.Query().Get("watch"), "true") {
-		flusher, ok := w.(http.Flusher)
-		if !ok {
-			http.Error(w, "streaming not supported by server", http.StatusInternalServerError)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json;stream=watch; charset=utf-8")
-		enc := json.NewEncoder(w)
-
-		allowBookmarks := strings.EqualFold(r.URL.Query().Get("allowWatchBookmarks"), "true")
-
-		rv := r.URL.Query().Get("resourceVersion")
-
-		var rvm = metav1.ResourceVersionMatchNotOlderThan
-		switch r.URL.Query().Get("resourceVersionMatch") {
-		case string(metav1.ResourceVersionMatchExact):
-			rvm = metav1.ResourceVersionMatchExact
-		case string(metav1.ResourceVersionMatchNotOlderThan):
-			rvm = metav1.ResourceVersionMatchNotOlderThan
-		}
-
-		sendInitial := rv == "" || rv == "0"
-		if sip := r.URL.Query().Get("sendInitialEvents"); sip != "" {
-			sendInitial = strings.EqualFold(sip, "true")
-		}
-
-		if sendInitial && rvm != metav1.ResourceVersionMatchNotOlderThan {
-			http.Error(w, "sendInitialEvents requires resourceVersionMatch=NotOlderThan", http.StatusBadRequest)
-			return
-		}
-
-		timeoutSec := int64(60)
-		if rawTimeoutSec := r.URL.Query().Get("timeoutSeconds"); rawTimeoutSec != "" {
-			if v, err := strconv.ParseInt(rawTimeoutSec, 10, 64); err == nil && v > 0 {
-				timeoutSec = v
-			}
-		}
-
-		fs := r.URL.Query().Get("fieldSelector")
-		if name != "" {
-			fs = "metadata.name=" + name
-		}
-
-		listOpts := metav1.ListOptions{
-			ResourceVersion:      rv,
-			ResourceVersionMatch: rvm,
-			TimeoutSeconds:       &timeoutSec,
-			SendInitialEvents:    &sendInitial,
-			Watch:                true,
-			AllowWatchBookmarks:  allowBookmarks,
-			LabelSelector:        r.URL.Query().Get("labelSelector"),
-			FieldSelector:        fs,
-		}
-
-		watcher, err := s.DynamicKubeCluient.Resource(newGVR).Namespace(namespace).Watch(r.Context(), listOpts)
-		if err != nil {
-			var se *apierrors.StatusError
-			if errors.As(err, &se) && se.ErrStatus.Code == http.StatusGone {
-				http.Error(w, err.Error(), http.StatusGone)
-				return
-			}
-
-			http.Error(w, "failed to initialize watcher: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		for {
-			select {
-			case <-r.Context().Done():
-				watcher.Stop()
-				return
-			case event, ok := <-watcher.ResultChan():
-				if !ok {
-					return
-				}
-
-				if event.Type == watch.Error {
-					if status, ok := event.Object.(*metav1.Status); ok {
-						http.Error(w, fmt.Sprintf("watch error: %s", status.Message), int(status.Code))
-						return
-					}
-
-					http.Error(w, "watch error: unknown", http.StatusInternalServerError)
-					return
-				} else if event.Type == watch.Bookmark {
-					if allowBookmarks {
-						if err := enc.Encode(event); err != nil {
-							http.Error(w, "failed to send bookmark: "+err.Error(), http.StatusInternalServerError)
-							return
-						}
-
-						flusher.Flush()
-					}
-
-					continue
-				} else if event.Object == nil {
-					continue
-				}
-
-				event.Object.GetObjectKind().SetGroupVersionKind(newKind)
-
-				unstructuredObj, ok := event.Object.(*unstructured.Unstructured)
-				if !ok {
-					http.Error(w, "failed to cast to unstructured", http.StatusInternalServerError)
-					return
-				}
-
-				var res any = unstructuredObj
-				if watchCallback != nil {
-					res, err = watchCallback(r.Context(), namespace, name, unstructuredObj)
-					if err != nil {
-						http.Error(w, fmt.Sprintf("watch error: %s", err.Error()), http.StatusInternalServerError)
-						return
-					}
-				}
-
-				if err = enc.Encode(watchEvent{
-					Type:   event.Type,
-					Object: res,
-				}); err != nil {
-					http.Error(w, "failed to encode watch response: "+err.Error(), http.StatusInternalServerError)
-					return
-				}
-
-				flusher.Flush()
-			}
-		}
-	}
-
-	_, items := createNewList()
-	if name != "" {
-		_, item := createNew()
-		if err := s.KubeClient.Get(r.Context(), client.ObjectKey{
-			Namespace: namespace, Name: name,
-		}, item); err != nil {
-			if client.IgnoreNotFound(err) != nil {
-				http.Error(w, "failed to get task: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
-
-			http.NotFound(w, r)
-			return
-		}
-
-		items.SetResourceVersion(item.GetResourceVersion())
-		meta.SetList(items, []runtime.Object{item})
-	} else {
-		var limit int64 = 500
-		if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
-			if l, err := strconv.ParseInt(rawLimit, 10, 64); err == nil && l > 0 && l <= 1000 {
-				limit = l
-			}
-		}
-
-		listOpts := client.ListOptions{
-			Namespace: namespace,
-			Limit:     limit,
-			Continue:  r.URL.Query().Get("continue"),
-			Raw:       &metav1.ListOptions{},
-		}
-
-		if ls := r.URL.Query().Get("labelSelector"); ls != "" {
-			selector, err := labels.Parse(ls)
-			if err != nil {
-				http.Error(w, "failed to parse labelSelector: "+err.Error(), http.StatusBadRequest)
-				return
-			}
-			listOpts.LabelSelector = selector
-		}
-
-		if fs := r.URL.Query().Get("fieldSelector"); fs != "" {
-			selector, err := fields.ParseSelector(fs)
-			if err != nil {
-				http.Error(w, "failed to parse fieldSelector: "+err.Error(), http.StatusBadRequest)
-				return
-			}
-			listOpts.FieldSelector = selector
-		}
-
-		if rv := r.URL.Query().Get("resourceVersion"); rv != "" {
-			listOpts.Raw.ResourceVersion = rv
-		}
-
-		listOpts.Raw.ResourceVersionMatch = metav1.ResourceVersionMatchNotOlderThan
-		switch r.URL.Query().Get("resourceVersionMatch") {
-		case string(metav1.ResourceVersionMatchExact):
-			listOpts.Raw.ResourceVersionMatch = metav1.ResourceVersionMatchExact
-		case string(metav1.ResourceVersionMatchNotOlderThan):
-			listOpts.Raw.ResourceVersionMatch = metav1.ResourceVersionMatchNotOlderThan
-		}
-
-		if err := s.KubeClient.List(r.Context(), items, &listOpts); err != nil {
-			var se *apierrors.StatusError
-			if errors.As(err, &se) && se.ErrStatus.Code == http.StatusGone {
-				http.Error(w, err.Error(), http.StatusGone)
-				return
-			}
-
-			http.Error(w, "failed to list tasks: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
-
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-
-	items.GetObjectKind().SetGroupVersionKind(newKindList)
-
-	if meta.LenList(items) == 0 {
-		if err := json.NewEncoder(w).Encode(items); err != nil {
-			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
-		}
-		return
-	}
-
-	if err := meta.EachListItem(items, func(o runtime.Object) error {
-		o.GetObjectKind().SetGroupVersionKind(newKind)
-		return nil
-	}); err != nil {
-		http.Error(w, "failed to set group version kind: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	var res any = items
-	if listCallback != nil {
-		var err error
-		res, err = listCallback(r.Context(), namespace, name, items)
-		if err != nil {
-			http.Error(w, "failed to list tasks: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
-
-	if name != "" {
-		if itemList, ok := res.(runtime.Object); ok {
-			if items, err := meta.ExtractList(itemList); err == nil {
-				res = items[0]
-			}
-		}
-	}
-
-	if err := json.NewEncoder(w).Encode(res); err != nil {
-		http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-}
@@ -0,0 +1,146 @@
+package framework
+
+import (
+	"net/http"
+	"time"
+)
+
+// AuditStage identifies which phase of a request's lifecycle an AuditEvent
+// describes, mirroring the stages the apiserver's audit log emits for every
+// request.
+type AuditStage string
+
+const (
+	AuditStageRequestReceived  AuditStage = "RequestReceived"
+	AuditStageResponseStarted  AuditStage = "ResponseStarted"
+	AuditStageResponseComplete AuditStage = "ResponseComplete"
+	AuditStagePanic            AuditStage = "Panic"
+)
+
+// AuditEvent describes one stage of one request, the way the apiserver's
+// audit.Event does, trimmed to the fields this framework can actually fill
+// in without a full authentication/authorization stack.
+type AuditEvent struct {
+	Stage      AuditStage
+	Timestamp  time.Time
+	Verb       string
+	RequestURI string
+	User       string
+	SourceIP   string
+	StatusCode int
+	Panic      any
+}
+
+// AuditSink receives audit events as they're produced. Implementations
+// should return quickly; auditMiddleware, which Start wraps around the
+// server's handler, calls ProcessEvents synchronously on the request's
+// goroutine.
+type AuditSink interface {
+	ProcessEvents(events ...*AuditEvent)
+}
+
+// auditMiddleware emits a RequestReceived event before next runs, a
+// ResponseStarted event on the first byte written, a ResponseComplete event
+// once next returns, and a Panic event (which it re-panics after emitting)
+// if next panics. A nil sink makes this a no-op passthrough.
+func auditMiddleware(sink AuditSink) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if sink == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			base := &AuditEvent{
+				Verb:       r.Method,
+				RequestURI: r.URL.RequestURI(),
+				User:       auditUser(r),
+				SourceIP:   r.RemoteAddr,
+			}
+
+			received := *base
+			received.Stage = AuditStageRequestReceived
+			received.Timestamp = time.Now()
+			sink.ProcessEvents(&received)
+
+			arw := &auditResponseWriter{ResponseWriter: w, sink: sink, base: base}
+
+			defer func() {
+				if p := recover(); p != nil {
+					event := *base
+					event.Stage = AuditStagePanic
+					event.Timestamp = time.Now()
+					event.StatusCode = arw.statusCode
+					event.Panic = p
+					sink.ProcessEvents(&event)
+					panic(p)
+				}
+
+				complete := *base
+				complete.Stage = AuditStageResponseComplete
+				complete.Timestamp = time.Now()
+				complete.StatusCode = arw.statusCode
+				sink.ProcessEvents(&complete)
+			}()
+
+			next.ServeHTTP(arw, r)
+		})
+	}
+}
+
+// auditUser identifies the caller from their client certificate, or from the
+// Impersonate-User header when the caller is impersonating another
+// identity, the way the apiserver's impersonation filter does.
+func auditUser(r *http.Request) string {
+	if u := r.Header.Get("Impersonate-User"); u != "" {
+		return u
+	}
+
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	return "system:anonymous"
+}
+
+// auditResponseWriter emits a single ResponseStarted event on the first
+// write, then passes everything through untouched.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	sink        AuditSink
+	base        *AuditEvent
+	statusCode  int
+	startedSent bool
+}
+
+func (a *auditResponseWriter) start(statusCode int) {
+	a.statusCode = statusCode
+
+	if a.startedSent {
+		return
+	}
+	a.startedSent = true
+
+	event := *a.base
+	event.Stage = AuditStageResponseStarted
+	event.Timestamp = time.Now()
+	event.StatusCode = statusCode
+	a.sink.ProcessEvents(&event)
+}
+
+func (a *auditResponseWriter) WriteHeader(statusCode int) {
+	a.start(statusCode)
+	a.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (a *auditResponseWriter) Write(b []byte) (int, error) {
+	if !a.startedSent {
+		a.start(http.StatusOK)
+	}
+	return a.ResponseWriter.Write(b)
+}
+
+func (a *auditResponseWriter) Flush() {
+	if f, ok := a.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,58 @@
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// handleCreate serves POST /apis/<group>/<version>/<resource>, creating a new object.
+func (rr *resourceRequest) handleCreate(w http.ResponseWriter, r *http.Request) {
+	s := rr.srv
+	res := rr.res
+
+	newGVR, obj := res.CreateNew()
+	newKind, err := s.KubeClient.RESTMapper().KindFor(newGVR)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to find kind: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(obj); err != nil {
+		http.Error(w, "failed to decode request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if rr.namespace != "" {
+		obj.SetNamespace(rr.namespace)
+	}
+
+	mutated, err := runAdmission(r.Context(), res.MutatingAdmission, rr.namespace, obj.GetName(), obj)
+	if err != nil {
+		http.Error(w, "admission denied: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	obj = mutated.(client.Object)
+
+	if err := s.KubeClient.Create(r.Context(), obj); err != nil {
+		http.Error(w, "failed to create resource: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	obj.GetObjectKind().SetGroupVersionKind(newKind)
+
+	var out any = obj
+	if res.CreateCallback != nil {
+		out, err = res.CreateCallback(r.Context(), rr.namespace, obj.GetName(), obj)
+		if err != nil {
+			http.Error(w, "failed to create resource: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := writeResponse(w, r, s.KubeClient.Scheme(), http.StatusCreated, out); err != nil {
+		http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+	}
+}
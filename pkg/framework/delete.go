@@ -0,0 +1,153 @@
+package framework
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// handleDelete serves DELETE /apis/<group>/<version>/<resource>/<name>,
+// honoring DeleteOptions.PropagationPolicy and GracePeriodSeconds.
+func (rr *resourceRequest) handleDelete(w http.ResponseWriter, r *http.Request) {
+	s := rr.srv
+	res := rr.res
+
+	_, obj := res.CreateNew()
+	if err := s.KubeClient.Get(r.Context(), client.ObjectKey{Namespace: rr.namespace, Name: rr.name}, obj); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			http.Error(w, "failed to get resource: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.NotFound(w, r)
+		return
+	}
+
+	opts, err := parseDeleteOptions(r)
+	if err != nil {
+		http.Error(w, "failed to decode delete options: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.KubeClient.Delete(r.Context(), obj, deleteOptionsFor(opts)...); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.NotFound(w, r)
+			return
+		}
+
+		http.Error(w, "failed to delete resource: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var out any = obj
+	if res.DeleteCallback != nil {
+		out, err = res.DeleteCallback(r.Context(), rr.namespace, rr.name, opts)
+		if err != nil {
+			http.Error(w, "failed to delete resource: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := writeResponse(w, r, s.KubeClient.Scheme(), http.StatusOK, out); err != nil {
+		http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleDeleteCollection serves DELETE on the collection URL (no name),
+// honoring the same DeleteOptions plus an optional labelSelector.
+func (rr *resourceRequest) handleDeleteCollection(w http.ResponseWriter, r *http.Request) {
+	s := rr.srv
+	res := rr.res
+
+	opts, err := parseDeleteOptions(r)
+	if err != nil {
+		http.Error(w, "failed to decode delete options: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, emptyObj := res.CreateNew()
+
+	deleteAllOpts := []client.DeleteAllOfOption{client.InNamespace(rr.namespace)}
+	deleteAllOpts = append(deleteAllOpts, deleteAllOfOptionsFor(opts)...)
+
+	if ls := r.URL.Query().Get("labelSelector"); ls != "" {
+		selector, err := labels.Parse(ls)
+		if err != nil {
+			http.Error(w, "failed to parse labelSelector: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		deleteAllOpts = append(deleteAllOpts, client.MatchingLabelsSelector{Selector: selector})
+	}
+
+	if err := s.KubeClient.DeleteAllOf(r.Context(), emptyObj, deleteAllOpts...); err != nil {
+		http.Error(w, "failed to delete resources: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeResponse(w, r, s.KubeClient.Scheme(), http.StatusOK, &metav1.Status{Status: metav1.StatusSuccess}); err != nil {
+		http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// parseDeleteOptions decodes a metav1.DeleteOptions body the way kubectl
+// sends it on DELETE, falling back to the equivalent query parameters.
+func parseDeleteOptions(r *http.Request) (*metav1.DeleteOptions, error) {
+	opts := &metav1.DeleteOptions{}
+
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(opts); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	if opts.PropagationPolicy == nil {
+		if pp := r.URL.Query().Get("propagationPolicy"); pp != "" {
+			policy := metav1.DeletionPropagation(pp)
+			opts.PropagationPolicy = &policy
+		}
+	}
+
+	if opts.GracePeriodSeconds == nil {
+		if gp := r.URL.Query().Get("gracePeriodSeconds"); gp != "" {
+			if v, err := strconv.ParseInt(gp, 10, 64); err == nil {
+				opts.GracePeriodSeconds = &v
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+func deleteOptionsFor(opts *metav1.DeleteOptions) []client.DeleteOption {
+	var out []client.DeleteOption
+
+	if opts.PropagationPolicy != nil {
+		out = append(out, client.PropagationPolicy(*opts.PropagationPolicy))
+	}
+
+	if opts.GracePeriodSeconds != nil {
+		out = append(out, client.GracePeriodSeconds(*opts.GracePeriodSeconds))
+	}
+
+	return out
+}
+
+func deleteAllOfOptionsFor(opts *metav1.DeleteOptions) []client.DeleteAllOfOption {
+	var out []client.DeleteAllOfOption
+
+	if opts.PropagationPolicy != nil {
+		out = append(out, client.PropagationPolicy(*opts.PropagationPolicy))
+	}
+
+	if opts.GracePeriodSeconds != nil {
+		out = append(out, client.GracePeriodSeconds(*opts.GracePeriodSeconds))
+	}
+
+	return out
+}
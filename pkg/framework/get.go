@@ -0,0 +1,84 @@
+package framework
+
+import (
+	"fmt"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// handleGet serves a single-object GET, e.g. GET /apis/<group>/<version>/<resource>/<name>.
+func (rr *resourceRequest) handleGet(w http.ResponseWriter, r *http.Request) {
+	s := rr.srv
+	res := rr.res
+
+	newGVR, _ := res.CreateNew()
+	newKind, err := s.KubeClient.RESTMapper().KindFor(newGVR)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to find kind: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	newListGVR, _ := res.CreateNewList()
+	newKindList, err := s.KubeClient.RESTMapper().KindFor(newListGVR)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to find list kind: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	_, item := res.CreateNew()
+	if err := s.KubeClient.Get(r.Context(), client.ObjectKey{Namespace: rr.namespace, Name: rr.name}, item); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			http.Error(w, "failed to get resource: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.NotFound(w, r)
+		return
+	}
+
+	_, items := res.CreateNewList()
+	items.GetObjectKind().SetGroupVersionKind(newKindList)
+	items.SetResourceVersion(item.GetResourceVersion())
+	meta.SetList(items, []runtime.Object{item})
+
+	if err := meta.EachListItem(items, func(o runtime.Object) error {
+		o.GetObjectKind().SetGroupVersionKind(newKind)
+		return nil
+	}); err != nil {
+		http.Error(w, "failed to set group version kind: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var out any = items
+	if res.ListCallback != nil {
+		out, err = res.ListCallback(r.Context(), rr.namespace, rr.name, items)
+		if err != nil {
+			http.Error(w, "failed to get resource: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if itemList, ok := out.(runtime.Object); ok {
+		if extracted, err := meta.ExtractList(itemList); err == nil && len(extracted) > 0 {
+			out = extracted[0]
+		}
+	}
+
+	if rr.tableConvertor.TableRow != nil && wantsTable(r) {
+		if obj, ok := out.(runtime.Object); ok {
+			table, err := convertToTable(rr.tableConvertor, tableIncludeObjectPolicy(r), []runtime.Object{obj})
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to convert to table: %s", err.Error()), http.StatusInternalServerError)
+				return
+			}
+			out = table
+		}
+	}
+
+	if err := writeResponse(w, r, s.KubeClient.Scheme(), http.StatusOK, out); err != nil {
+		http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+	}
+}
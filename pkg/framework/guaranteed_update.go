@@ -0,0 +1,110 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxGuaranteedUpdateRetries bounds how many attempts GuaranteedUpdate makes
+// in total (the first try plus retries after losing an
+// optimistic-concurrency race), the way etcd3's
+// storage.Interface.GuaranteedUpdate bounds its own retry loop.
+const maxGuaranteedUpdateRetries = 5
+
+// Preconditions constrains a GuaranteedUpdate to the object identity the
+// caller expects to still be current, mirroring metav1.Preconditions.
+type Preconditions struct {
+	UID             *types.UID
+	ResourceVersion *string
+}
+
+func (p *Preconditions) check(gr schema.GroupResource, obj client.Object) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.UID != nil && *p.UID != obj.GetUID() {
+		return apierrors.NewConflict(gr, obj.GetName(), fmt.Errorf(
+			"precondition failed: UID in precondition: %q, UID in object meta: %q", *p.UID, obj.GetUID()))
+	}
+
+	if p.ResourceVersion != nil && *p.ResourceVersion != obj.GetResourceVersion() {
+		return apierrors.NewConflict(gr, obj.GetName(), fmt.Errorf(
+			"precondition failed: ResourceVersion in precondition: %q, ResourceVersion in object meta: %q",
+			*p.ResourceVersion, obj.GetResourceVersion()))
+	}
+
+	return nil
+}
+
+// GuaranteedUpdate reads the object at key and hands it to tryUpdate, which
+// is responsible for both computing and persisting the new state (via
+// whatever write verb fits — Update for a whole-object replace, Patch for a
+// partial one) and returning the result. If the write loses an
+// optimistic-concurrency race, GuaranteedUpdate re-fetches and calls
+// tryUpdate again, the same fetch/mutate/compare/retry cycle etcd3's
+// GuaranteedUpdate runs under the hood for every CustomResource write.
+//
+// newEmpty must return a fresh zero-value object of key's type on each call:
+// GuaranteedUpdate decodes the live object into it on every attempt.
+func GuaranteedUpdate(
+	ctx context.Context,
+	c client.Client,
+	gvr schema.GroupVersionResource,
+	key client.ObjectKey,
+	newEmpty func() client.Object,
+	preconditions *Preconditions,
+	tryUpdate func(current client.Object) (client.Object, error),
+) (client.Object, error) {
+	gr := gvr.GroupResource()
+
+	var lastErr error
+	for attempt := 0; attempt < maxGuaranteedUpdateRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(guaranteedUpdateBackoff(attempt)):
+			}
+		}
+
+		current := newEmpty()
+		if err := c.Get(ctx, key, current); err != nil {
+			return nil, err
+		}
+
+		if err := preconditions.check(gr, current); err != nil {
+			return nil, err
+		}
+
+		updated, err := tryUpdate(current)
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				lastErr = err
+				continue
+			}
+
+			return nil, err
+		}
+
+		return updated, nil
+	}
+
+	return nil, fmt.Errorf("GuaranteedUpdate: gave up after %d attempts, last error: %w", maxGuaranteedUpdateRetries, lastErr)
+}
+
+// guaranteedUpdateBackoff grows linearly and caps at 200ms: enough to let a
+// competing writer finish without stalling the request for long.
+func guaranteedUpdateBackoff(attempt int) time.Duration {
+	d := time.Duration(attempt) * 20 * time.Millisecond
+	if d > 200*time.Millisecond {
+		d = 200 * time.Millisecond
+	}
+	return d
+}
@@ -0,0 +1,130 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeGetClient implements only Get; GuaranteedUpdate never calls any other
+// client.Client method, so the rest can be left as a nil embedded interface.
+type fakeGetClient struct {
+	client.Client
+	getCalls int
+}
+
+func (f *fakeGetClient) Get(_ context.Context, _ client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	f.getCalls++
+	obj.(*unstructured.Unstructured).SetResourceVersion("1")
+	return nil
+}
+
+var testGVR = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+func TestGuaranteedUpdateRetriesOnConflict(t *testing.T) {
+	fc := &fakeGetClient{}
+
+	attempts := 0
+	tryUpdate := func(current client.Object) (client.Object, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, apierrors.NewConflict(testGVR.GroupResource(), "widget-a", errors.New("conflict"))
+		}
+		return current, nil
+	}
+
+	result, err := GuaranteedUpdate(context.Background(), fc, testGVR,
+		client.ObjectKey{Name: "widget-a"},
+		func() client.Object { return &unstructured.Unstructured{} },
+		nil, tryUpdate)
+	if err != nil {
+		t.Fatalf("GuaranteedUpdate: %v", err)
+	}
+	if result == nil {
+		t.Fatal("expected a result")
+	}
+	if attempts != 3 {
+		t.Fatalf("expected tryUpdate to run 3 times, got %d", attempts)
+	}
+	if fc.getCalls != 3 {
+		t.Fatalf("expected Get to run 3 times, got %d", fc.getCalls)
+	}
+}
+
+func TestGuaranteedUpdateGivesUpAfterMaxRetries(t *testing.T) {
+	fc := &fakeGetClient{}
+
+	tryUpdate := func(current client.Object) (client.Object, error) {
+		return nil, apierrors.NewConflict(testGVR.GroupResource(), "widget-a", errors.New("conflict"))
+	}
+
+	_, err := GuaranteedUpdate(context.Background(), fc, testGVR,
+		client.ObjectKey{Name: "widget-a"},
+		func() client.Object { return &unstructured.Unstructured{} },
+		nil, tryUpdate)
+	if err == nil {
+		t.Fatal("expected an error after exhausting retries")
+	}
+	if fc.getCalls != maxGuaranteedUpdateRetries {
+		t.Fatalf("expected Get to run %d times, got %d", maxGuaranteedUpdateRetries, fc.getCalls)
+	}
+}
+
+func TestGuaranteedUpdateNonConflictErrorStopsImmediately(t *testing.T) {
+	fc := &fakeGetClient{}
+
+	wantErr := errors.New("boom")
+	tryUpdate := func(current client.Object) (client.Object, error) {
+		return nil, wantErr
+	}
+
+	_, err := GuaranteedUpdate(context.Background(), fc, testGVR,
+		client.ObjectKey{Name: "widget-a"},
+		func() client.Object { return &unstructured.Unstructured{} },
+		nil, tryUpdate)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected wantErr, got %v", err)
+	}
+	if fc.getCalls != 1 {
+		t.Fatalf("expected Get to run once (no retry on non-conflict error), got %d", fc.getCalls)
+	}
+}
+
+func TestGuaranteedUpdatePreconditionUIDMismatch(t *testing.T) {
+	fc := &fakeGetClient{}
+
+	uid := types.UID("wrong-uid")
+	_, err := GuaranteedUpdate(context.Background(), fc, testGVR,
+		client.ObjectKey{Name: "widget-a"},
+		func() client.Object { return &unstructured.Unstructured{} },
+		&Preconditions{UID: &uid},
+		func(current client.Object) (client.Object, error) { return current, nil })
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict error from the precondition check, got %v", err)
+	}
+	if fc.getCalls != 1 {
+		t.Fatalf("expected Get to run once, got %d", fc.getCalls)
+	}
+}
+
+func TestGuaranteedUpdateBackoffGrowsAndCaps(t *testing.T) {
+	if guaranteedUpdateBackoff(0) != 0 {
+		t.Errorf("expected zero backoff on attempt 0, got %v", guaranteedUpdateBackoff(0))
+	}
+	if guaranteedUpdateBackoff(1) != 20*time.Millisecond {
+		t.Errorf("expected 20ms backoff on attempt 1, got %v", guaranteedUpdateBackoff(1))
+	}
+	if guaranteedUpdateBackoff(3) != 60*time.Millisecond {
+		t.Errorf("expected 60ms backoff on attempt 3, got %v", guaranteedUpdateBackoff(3))
+	}
+	if guaranteedUpdateBackoff(100) != 200*time.Millisecond {
+		t.Errorf("expected backoff to cap at 200ms, got %v", guaranteedUpdateBackoff(100))
+	}
+}
@@ -0,0 +1,68 @@
+package framework
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gzipMiddleware wraps next so that responses are gzip-compressed whenever
+// the client's Accept-Encoding allows it.
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := gzip.NewWriter(w)
+		defer gw.Close()
+
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		next.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, writer: gw}, r)
+	})
+}
+
+func acceptsGzip(acceptEncoding string) bool {
+	for _, enc := range strings.Split(acceptEncoding, ",") {
+		parts := strings.SplitN(enc, ";", 2)
+		if !strings.EqualFold(strings.TrimSpace(parts[0]), "gzip") {
+			continue
+		}
+
+		// "gzip;q=0" explicitly refuses gzip.
+		if len(parts) == 2 {
+			if param := strings.TrimSpace(parts[1]); strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil && v == 0 {
+					return false
+				}
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// gzipResponseWriter transparently gzips everything written to it. Flush
+// passes through to the underlying http.Flusher so long-running watch
+// requests still deliver events as they arrive.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	writer *gzip.Writer
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	return g.writer.Write(b)
+}
+
+func (g *gzipResponseWriter) Flush() {
+	g.writer.Flush()
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,204 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// handleList serves a collection GET, e.g. GET /apis/<group>/<version>/<resource>.
+//
+// labelSelector is forwarded to the upstream store as a prefilter, but
+// fieldSelector is not (see matchesSelectors); pagination of the
+// selector-filtered result is done in the framework too, via continueToken.
+func (rr *resourceRequest) handleList(w http.ResponseWriter, r *http.Request) {
+	s := rr.srv
+	res := rr.res
+
+	newGVR, _ := res.CreateNew()
+	newKind, err := s.KubeClient.RESTMapper().KindFor(newGVR)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to find kind: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	newListGVR, _ := res.CreateNewList()
+	newKindList, err := s.KubeClient.RESTMapper().KindFor(newListGVR)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to find list kind: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	_, items := res.CreateNewList()
+
+	var limit int64 = 500
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		if l, err := strconv.ParseInt(rawLimit, 10, 64); err == nil && l > 0 && l <= 1000 {
+			limit = l
+		}
+	}
+
+	var offset int
+	var continueResourceVersion string
+	if c := r.URL.Query().Get("continue"); c != "" {
+		token, err := decodeContinueToken(c)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		offset = token.Offset
+		continueResourceVersion = token.ResourceVersion
+	}
+
+	var labelSelector labels.Selector
+	if ls := r.URL.Query().Get("labelSelector"); ls != "" {
+		labelSelector, err = labels.Parse(ls)
+		if err != nil {
+			http.Error(w, "failed to parse labelSelector: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var fieldSelector fields.Selector
+	if fs := r.URL.Query().Get("fieldSelector"); fs != "" {
+		fieldSelector, err = fields.ParseSelector(fs)
+		if err != nil {
+			http.Error(w, "failed to parse fieldSelector: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	listOpts := client.ListOptions{
+		Namespace:     rr.namespace,
+		LabelSelector: labelSelector,
+		Raw:           &metav1.ListOptions{},
+	}
+
+	if rv := r.URL.Query().Get("resourceVersion"); rv != "" {
+		listOpts.Raw.ResourceVersion = rv
+	}
+
+	listOpts.Raw.ResourceVersionMatch = metav1.ResourceVersionMatchNotOlderThan
+	switch r.URL.Query().Get("resourceVersionMatch") {
+	case string(metav1.ResourceVersionMatchExact):
+		listOpts.Raw.ResourceVersionMatch = metav1.ResourceVersionMatchExact
+	case string(metav1.ResourceVersionMatchNotOlderThan):
+		listOpts.Raw.ResourceVersionMatch = metav1.ResourceVersionMatchNotOlderThan
+	}
+
+	// A continuation pins every subsequent page to the resourceVersion the
+	// first page was listed at, the same snapshot consistency a real
+	// continue token gives you — otherwise items created, deleted or
+	// relabeled between pages would shift the in-memory offset relative to
+	// what the client already saw.
+	if continueResourceVersion != "" {
+		listOpts.Raw.ResourceVersion = continueResourceVersion
+		listOpts.Raw.ResourceVersionMatch = metav1.ResourceVersionMatchExact
+	}
+
+	if err := s.KubeClient.List(r.Context(), items, &listOpts); err != nil {
+		var se *apierrors.StatusError
+		if errors.As(err, &se) && se.ErrStatus.Code == http.StatusGone {
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+
+		http.Error(w, "failed to list resources: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items.GetObjectKind().SetGroupVersionKind(newKindList)
+
+	var matched []runtime.Object
+	if err := meta.EachListItem(items, func(o runtime.Object) error {
+		o.GetObjectKind().SetGroupVersionKind(newKind)
+
+		obj, ok := o.(client.Object)
+		if !ok {
+			return fmt.Errorf("list item of type %T does not implement client.Object", o)
+		}
+
+		if matchesSelectors(res, obj, labelSelector, fieldSelector) {
+			matched = append(matched, o)
+		}
+
+		return nil
+	}); err != nil {
+		http.Error(w, "failed to filter resources: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if offset > len(matched) {
+		http.Error(w, "invalid continue token: offset past end of result", http.StatusBadRequest)
+		return
+	}
+
+	page := matched[offset:]
+	remainingContinue := ""
+	if int64(len(page)) > limit {
+		page = page[:limit]
+		remainingContinue = encodeContinueToken(continueToken{
+			ResourceVersion: items.GetResourceVersion(),
+			Offset:          offset + len(page),
+		})
+	}
+
+	if err := meta.SetList(items, page); err != nil {
+		http.Error(w, "failed to build response page: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	items.GetObjectKind().SetGroupVersionKind(newKindList)
+	items.SetContinue(remainingContinue)
+
+	if len(page) == 0 {
+		var out any = items
+		if rr.tableConvertor.TableRow != nil && wantsTable(r) {
+			table, err := convertToTable(rr.tableConvertor, tableIncludeObjectPolicy(r), nil)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to convert to table: %s", err.Error()), http.StatusInternalServerError)
+				return
+			}
+			out = table
+		}
+
+		if err := writeResponse(w, r, s.KubeClient.Scheme(), http.StatusOK, out); err != nil {
+			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var out any = items
+	if res.ListCallback != nil {
+		out, err = res.ListCallback(r.Context(), rr.namespace, "", items)
+		if err != nil {
+			http.Error(w, "failed to list resources: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if rr.tableConvertor.TableRow != nil && wantsTable(r) {
+		if obj, ok := out.(runtime.Object); ok {
+			if extracted, err := meta.ExtractList(obj); err == nil {
+				table, err := convertToTable(rr.tableConvertor, tableIncludeObjectPolicy(r), extracted)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("failed to convert to table: %s", err.Error()), http.StatusInternalServerError)
+					return
+				}
+				out = table
+			}
+		}
+	}
+
+	if err := writeResponse(w, r, s.KubeClient.Scheme(), http.StatusOK, out); err != nil {
+		http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+	}
+}
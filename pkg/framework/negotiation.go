@@ -0,0 +1,105 @@
+package framework
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+// negotiateSerializerInfo parses the request's Accept header against the
+// scheme's CodecFactory and returns the best matching serializer, the way
+// client-go's content negotiation does. It supports application/json,
+// application/yaml and application/vnd.kubernetes.protobuf, and falls back
+// to JSON when the client didn't ask for anything else, or asked for
+// something the scheme doesn't support.
+//
+// obj is consulted to skip protobuf: every Resource in this framework is
+// backed by *unstructured.Unstructured, which doesn't implement
+// runtime.ProtobufMarshaller, so negotiating protobuf for it would only fail
+// at encode time.
+func negotiateSerializerInfo(scheme *runtime.Scheme, r *http.Request, obj runtime.Object) (runtime.SerializerInfo, string) {
+	supported := serializer.NewCodecFactory(scheme).SupportedMediaTypes()
+	protobufCapable := canEncodeProtobuf(obj)
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		for _, info := range supported {
+			if info.MediaType != mediaType {
+				continue
+			}
+			if mediaType == runtime.ContentTypeProtobuf && !protobufCapable {
+				continue
+			}
+			return info, info.MediaType
+		}
+	}
+
+	info, _ := runtime.SerializerInfoForMediaType(supported, runtime.ContentTypeJSON)
+	return info, info.MediaType
+}
+
+// canEncodeProtobuf reports whether obj implements one of the marshalling
+// interfaces k8s.io/apimachinery's protobuf serializer requires to encode an
+// object (see protobuf.Serializer.Encode); *unstructured.Unstructured, which
+// backs every Resource in this framework, implements none of them.
+func canEncodeProtobuf(obj runtime.Object) bool {
+	switch obj.(type) {
+	case runtime.ProtobufMarshaller, runtime.ProtobufReverseMarshaller:
+		return true
+	}
+
+	_, ok := obj.(interface {
+		Marshal() ([]byte, error)
+	})
+	return ok
+}
+
+// writeResponse encodes obj according to the client's negotiated Accept
+// header and writes it with statusCode. Values that don't implement
+// runtime.Object (e.g. a custom type returned by a ListCallback) can't be
+// serialized as YAML/protobuf by the scheme, so they're always encoded as
+// plain JSON, matching their previous behavior.
+//
+// The encode happens into a buffer before anything is written to w: if it
+// fails, the caller can still send a clean error status instead of
+// WriteHeader having already committed 200.
+func writeResponse(w http.ResponseWriter, r *http.Request, scheme *runtime.Scheme, statusCode int, obj any) error {
+	robj, ok := obj.(runtime.Object)
+	if !ok {
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(obj); err != nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(statusCode)
+		_, err := buf.WriteTo(w)
+		return err
+	}
+
+	info, mediaType := negotiateSerializerInfo(scheme, r, robj)
+
+	contentType := mediaType
+	if mediaType == runtime.ContentTypeJSON || mediaType == runtime.ContentTypeYAML {
+		contentType += "; charset=utf-8"
+	}
+
+	var buf bytes.Buffer
+	if err := info.Serializer.Encode(robj, &buf); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,101 @@
+package framework
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// protobufCapableObject is a minimal runtime.Object that also implements
+// Marshal() ([]byte, error), one of the interfaces canEncodeProtobuf accepts
+// before negotiateSerializerInfo will hand out the protobuf serializer.
+type protobufCapableObject struct {
+	runtime.Object
+}
+
+func (protobufCapableObject) Marshal() ([]byte, error) { return nil, nil }
+
+func newTestScheme() *runtime.Scheme {
+	return runtime.NewScheme()
+}
+
+func TestNegotiateSerializerInfoFallsBackToJSONForNonProtobufObject(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/vnd.kubernetes.protobuf")
+
+	_, mediaType := negotiateSerializerInfo(newTestScheme(), r, &unstructured.Unstructured{})
+	if mediaType != runtime.ContentTypeJSON {
+		t.Fatalf("expected fallback to JSON for a non-protobuf-capable object, got %q", mediaType)
+	}
+}
+
+func TestNegotiateSerializerInfoUsesProtobufWhenCapable(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/vnd.kubernetes.protobuf")
+
+	_, mediaType := negotiateSerializerInfo(newTestScheme(), r, protobufCapableObject{Object: &unstructured.Unstructured{}})
+	if mediaType != runtime.ContentTypeProtobuf {
+		t.Fatalf("expected protobuf for a protobuf-capable object, got %q", mediaType)
+	}
+}
+
+// headerTrackingWriter records whether WriteHeader was ever called, so tests
+// can assert writeResponse doesn't commit a status before it knows encoding
+// succeeded.
+type headerTrackingWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *headerTrackingWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// unencodableObject is a runtime.Object with a field encoding/json can never
+// marshal, exercising writeResponse's "encode fails" path.
+type unencodableObject struct {
+	*metav1TypeMetaStub
+	Ch chan int
+}
+
+type metav1TypeMetaStub struct {
+	gvk schema.GroupVersionKind
+}
+
+func (s *metav1TypeMetaStub) GetObjectKind() schema.ObjectKind                { return s }
+func (s *metav1TypeMetaStub) SetGroupVersionKind(gvk schema.GroupVersionKind) { s.gvk = gvk }
+func (s *metav1TypeMetaStub) GroupVersionKind() schema.GroupVersionKind       { return s.gvk }
+func (o unencodableObject) DeepCopyObject() runtime.Object                    { return o }
+
+func TestWriteResponseDoesNotCommitStatusOnEncodeFailure(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	w := &headerTrackingWriter{ResponseWriter: rec}
+
+	err := writeResponse(w, r, newTestScheme(), http.StatusOK, unencodableObject{metav1TypeMetaStub: &metav1TypeMetaStub{}})
+	if err == nil {
+		t.Fatal("expected an encode error")
+	}
+	if w.wroteHeader {
+		t.Error("expected WriteHeader not to have been called before the encode error was known")
+	}
+}
+
+func TestWriteResponseNonRuntimeObjectEncodeFailureDoesNotCommitStatus(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	w := &headerTrackingWriter{ResponseWriter: rec}
+
+	err := writeResponse(w, r, newTestScheme(), http.StatusOK, make(chan int))
+	if err == nil {
+		t.Fatal("expected an encode error for an unmarshalable value")
+	}
+	if w.wroteHeader {
+		t.Error("expected WriteHeader not to have been called before the encode error was known")
+	}
+}
@@ -0,0 +1,287 @@
+package framework
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/kube-openapi/pkg/spec3"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+// OpenAPIDefinitions lets an APIKind contribute its object (and list) schema
+// to the /openapi/v2 and /openapi/v3 discovery documents. Most APIKinds can
+// leave this unset: schemas are only needed by clients that validate
+// requests against them, such as kubectl --validate or generated clients.
+type OpenAPIDefinitions struct {
+	// Schema describes a single object of this kind.
+	Schema *spec.Schema
+
+	// ListSchema describes the collection type returned by list/watch. If
+	// nil, no list definition is published.
+	ListSchema *spec.Schema
+}
+
+// definitionName is the key an APIKind's schemas are published under, e.g.
+// "example.com.v1.Widget".
+func definitionName(group, version, kind string) string {
+	return group + "." + version + "." + kind
+}
+
+// collectionAndItemPaths returns the two URL paths an APIKind's REST verbs
+// are served on, matching the routes NewServer registers for its Resources.
+func collectionAndItemPaths(config ServerConfig, ak APIKind) (collection, item string) {
+	collection = "/apis/" + config.Group + "/" + config.Version + "/" + ak.ApiResource.Name
+	if ak.ApiResource.Namespaced {
+		collection = "/apis/" + config.Group + "/" + config.Version + "/namespaces/{namespace}/" + ak.ApiResource.Name
+	}
+
+	return collection, collection + "/{name}"
+}
+
+// watchCollectionAndItemPaths returns the /watch/... paths the OpenAPI
+// documents list for watching a collection and a single object, mirroring
+// collectionAndItemPaths. NewServer registers no handler under /watch/:
+// handleWatch is reached only through ?watch=true on collectionAndItemPaths'
+// paths.
+func watchCollectionAndItemPaths(config ServerConfig, ak APIKind) (collection, item string) {
+	prefix := "/apis/" + config.Group + "/" + config.Version + "/watch/"
+
+	collection = prefix + ak.ApiResource.Name
+	if ak.ApiResource.Namespaced {
+		collection = prefix + "namespaces/{namespace}/" + ak.ApiResource.Name
+	}
+
+	return collection, collection + "/{name}"
+}
+
+// buildOpenAPIV2 assembles the swagger 2.0 document served at /openapi/v2:
+// list/create on each resource's collection path and get/replace/patch/delete
+// on its item path, with $ref responses pointing at whatever schemas the
+// APIKind published. The v2 operations carry no request body parameter.
+func buildOpenAPIV2(config ServerConfig) *spec.Swagger {
+	definitions := spec.Definitions{}
+	paths := map[string]spec.PathItem{}
+
+	for _, ak := range config.APIKinds {
+		objName := definitionName(config.Group, config.Version, ak.ApiResource.Kind)
+		listName := objName + "List"
+
+		if ak.OpenAPIDefinitions.Schema != nil {
+			definitions[objName] = *ak.OpenAPIDefinitions.Schema
+		}
+		if ak.OpenAPIDefinitions.ListSchema != nil {
+			definitions[listName] = *ak.OpenAPIDefinitions.ListSchema
+		}
+
+		collection, item := collectionAndItemPaths(config, ak)
+		kind := ak.ApiResource.Kind
+
+		paths[collection] = spec.PathItem{
+			PathItemProps: spec.PathItemProps{
+				Get:  v2Operation("list objects of kind "+kind, ak.OpenAPIDefinitions.ListSchema, v2Ref(listName)),
+				Post: v2Operation("create a "+kind, ak.OpenAPIDefinitions.Schema, v2Ref(objName)),
+			},
+		}
+
+		paths[item] = spec.PathItem{
+			PathItemProps: spec.PathItemProps{
+				Get:    v2Operation("read the specified "+kind, ak.OpenAPIDefinitions.Schema, v2Ref(objName)),
+				Put:    v2Operation("replace the specified "+kind, ak.OpenAPIDefinitions.Schema, v2Ref(objName)),
+				Patch:  v2Operation("partially update the specified "+kind, ak.OpenAPIDefinitions.Schema, v2Ref(objName)),
+				Delete: v2Operation("delete the specified "+kind, nil, spec.Schema{}),
+			},
+		}
+
+		watchCollection, watchItem := watchCollectionAndItemPaths(config, ak)
+
+		paths[watchCollection] = spec.PathItem{
+			PathItemProps: spec.PathItemProps{
+				Get: v2Operation("watch individual changes to a list of "+kind, ak.OpenAPIDefinitions.ListSchema, v2Ref(listName)),
+			},
+		}
+
+		paths[watchItem] = spec.PathItem{
+			PathItemProps: spec.PathItemProps{
+				Get: v2Operation("watch changes to the specified "+kind, ak.OpenAPIDefinitions.Schema, v2Ref(objName)),
+			},
+		}
+	}
+
+	return &spec.Swagger{
+		SwaggerProps: spec.SwaggerProps{
+			Swagger: "2.0",
+			Info: &spec.Info{
+				InfoProps: spec.InfoProps{
+					Title:   config.Group,
+					Version: config.Version,
+				},
+			},
+			Paths:       &spec.Paths{Paths: paths},
+			Definitions: definitions,
+		},
+	}
+}
+
+// v2Ref builds a schema that's nothing but a $ref to one of Definitions.
+func v2Ref(name string) spec.Schema {
+	return spec.Schema{SchemaProps: spec.SchemaProps{Ref: spec.MustCreateRef("#/definitions/" + name)}}
+}
+
+// v2Operation builds a minimal operation whose 200 response carries ref as
+// its schema, unless schema is nil (nothing published for this Kind/shape).
+func v2Operation(description string, schema *spec.Schema, ref spec.Schema) *spec.Operation {
+	resp := spec.Response{ResponseProps: spec.ResponseProps{Description: "OK"}}
+	if schema != nil {
+		s := ref
+		resp.Schema = &s
+	}
+
+	return &spec.Operation{
+		OperationProps: spec.OperationProps{
+			Description: description,
+			Responses: &spec.Responses{
+				ResponsesProps: spec.ResponsesProps{
+					StatusCodeResponses: map[int]spec.Response{http.StatusOK: resp},
+				},
+			},
+		},
+	}
+}
+
+// buildOpenAPIV3 assembles the OpenAPI v3 document served at
+// /openapi/v3/apis/<group>/<version>, mirroring buildOpenAPIV2's paths and
+// schemas in the v3 shape (response bodies under Content instead of a bare
+// Schema field). Unlike the v2 document, create/replace/patch here also get
+// a request body.
+func buildOpenAPIV3(config ServerConfig) *spec3.OpenAPI {
+	schemas := map[string]*spec.Schema{}
+	paths := map[string]*spec3.Path{}
+
+	for _, ak := range config.APIKinds {
+		objName := definitionName(config.Group, config.Version, ak.ApiResource.Kind)
+		listName := objName + "List"
+
+		if ak.OpenAPIDefinitions.Schema != nil {
+			schemas[objName] = ak.OpenAPIDefinitions.Schema
+		}
+		if ak.OpenAPIDefinitions.ListSchema != nil {
+			schemas[listName] = ak.OpenAPIDefinitions.ListSchema
+		}
+
+		collection, item := collectionAndItemPaths(config, ak)
+		kind := ak.ApiResource.Kind
+
+		paths[collection] = &spec3.Path{
+			PathProps: spec3.PathProps{
+				Get:  v3Operation("list objects of kind "+kind, ak.OpenAPIDefinitions.ListSchema, v3Ref(listName), false),
+				Post: v3Operation("create a "+kind, ak.OpenAPIDefinitions.Schema, v3Ref(objName), true),
+			},
+		}
+
+		paths[item] = &spec3.Path{
+			PathProps: spec3.PathProps{
+				Get:    v3Operation("read the specified "+kind, ak.OpenAPIDefinitions.Schema, v3Ref(objName), false),
+				Put:    v3Operation("replace the specified "+kind, ak.OpenAPIDefinitions.Schema, v3Ref(objName), true),
+				Patch:  v3Operation("partially update the specified "+kind, ak.OpenAPIDefinitions.Schema, v3Ref(objName), true),
+				Delete: v3Operation("delete the specified "+kind, nil, nil, false),
+			},
+		}
+
+		watchCollection, watchItem := watchCollectionAndItemPaths(config, ak)
+
+		paths[watchCollection] = &spec3.Path{
+			PathProps: spec3.PathProps{
+				Get: v3Operation("watch individual changes to a list of "+kind, ak.OpenAPIDefinitions.ListSchema, v3Ref(listName), false),
+			},
+		}
+
+		paths[watchItem] = &spec3.Path{
+			PathProps: spec3.PathProps{
+				Get: v3Operation("watch changes to the specified "+kind, ak.OpenAPIDefinitions.Schema, v3Ref(objName), false),
+			},
+		}
+	}
+
+	return &spec3.OpenAPI{
+		Version: "3.0.0",
+		Info: &spec.Info{
+			InfoProps: spec.InfoProps{
+				Title:   config.Group,
+				Version: config.Version,
+			},
+		},
+		Paths: &spec3.Paths{Paths: paths},
+		Components: &spec3.Components{
+			Schemas: schemas,
+		},
+	}
+}
+
+// v3Ref builds a schema that's nothing but a $ref into Components.Schemas.
+func v3Ref(name string) *spec.Schema {
+	return &spec.Schema{SchemaProps: spec.SchemaProps{Ref: spec.MustCreateRef("#/components/schemas/" + name)}}
+}
+
+// v3Operation builds a minimal operation whose 200 response (and, if
+// withRequestBody, request body) carries ref as its schema, unless schema is
+// nil (nothing published for this Kind/shape).
+func v3Operation(description string, schema *spec.Schema, ref *spec.Schema, withRequestBody bool) *spec3.Operation {
+	resp := &spec3.Response{ResponseProps: spec3.ResponseProps{Description: "OK"}}
+
+	op := &spec3.Operation{
+		OperationProps: spec3.OperationProps{
+			Description: description,
+			Responses: &spec3.Responses{
+				ResponsesProps: spec3.ResponsesProps{
+					StatusCodeResponses: map[int]*spec3.Response{http.StatusOK: resp},
+				},
+			},
+		},
+	}
+
+	if schema == nil {
+		return op
+	}
+
+	content := map[string]*spec3.MediaType{
+		"application/json": {MediaTypeProps: spec3.MediaTypeProps{Schema: ref}},
+	}
+	resp.Content = content
+
+	if withRequestBody {
+		op.RequestBody = &spec3.RequestBody{RequestBodyProps: spec3.RequestBodyProps{Content: content}}
+	}
+
+	return op
+}
+
+func registerOpenAPIHandlers(srv *Server, config ServerConfig) {
+	v2 := buildOpenAPIV2(config)
+	srv.mux.HandleFunc("/openapi/v2", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(v2)
+	})
+
+	v3 := buildOpenAPIV3(config)
+	srv.mux.HandleFunc("/openapi/v3/apis/"+config.Group+"/"+config.Version, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(v3)
+	})
+
+	srv.mux.HandleFunc("/openapi/v3", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(&struct {
+			Paths map[string]struct {
+				ServerRelativeURL string `json:"serverRelativeURL"`
+			} `json:"paths"`
+		}{
+			Paths: map[string]struct {
+				ServerRelativeURL string `json:"serverRelativeURL"`
+			}{
+				"apis/" + config.Group + "/" + config.Version: {
+					ServerRelativeURL: "/openapi/v3/apis/" + config.Group + "/" + config.Version,
+				},
+			},
+		})
+	})
+}
@@ -0,0 +1,73 @@
+package framework
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWatchCollectionAndItemPathsNamespaced(t *testing.T) {
+	config := ServerConfig{Group: "example.com", Version: "v1"}
+	ak := APIKind{ApiResource: metav1.APIResource{Name: "widgets", Namespaced: true}}
+
+	collection, item := watchCollectionAndItemPaths(config, ak)
+	if collection != "/apis/example.com/v1/watch/namespaces/{namespace}/widgets" {
+		t.Errorf("unexpected watch collection path: %s", collection)
+	}
+	if item != collection+"/{name}" {
+		t.Errorf("unexpected watch item path: %s", item)
+	}
+}
+
+func TestWatchCollectionAndItemPathsClusterScoped(t *testing.T) {
+	config := ServerConfig{Group: "example.com", Version: "v1"}
+	ak := APIKind{ApiResource: metav1.APIResource{Name: "widgets", Namespaced: false}}
+
+	collection, item := watchCollectionAndItemPaths(config, ak)
+	if collection != "/apis/example.com/v1/watch/widgets" {
+		t.Errorf("unexpected watch collection path: %s", collection)
+	}
+	if item != collection+"/{name}" {
+		t.Errorf("unexpected watch item path: %s", item)
+	}
+}
+
+func TestBuildOpenAPIV2IncludesWatchPaths(t *testing.T) {
+	config := ServerConfig{
+		Group:   "example.com",
+		Version: "v1",
+		APIKinds: []APIKind{
+			{ApiResource: metav1.APIResource{Name: "widgets", Kind: "Widget", Namespaced: true}},
+		},
+	}
+
+	swagger := buildOpenAPIV2(config)
+	watchCollection, watchItem := watchCollectionAndItemPaths(config, config.APIKinds[0])
+
+	if _, ok := swagger.Paths.Paths[watchCollection]; !ok {
+		t.Errorf("expected a watch path item at %s", watchCollection)
+	}
+	if _, ok := swagger.Paths.Paths[watchItem]; !ok {
+		t.Errorf("expected a watch path item at %s", watchItem)
+	}
+}
+
+func TestBuildOpenAPIV3IncludesWatchPaths(t *testing.T) {
+	config := ServerConfig{
+		Group:   "example.com",
+		Version: "v1",
+		APIKinds: []APIKind{
+			{ApiResource: metav1.APIResource{Name: "widgets", Kind: "Widget", Namespaced: true}},
+		},
+	}
+
+	doc := buildOpenAPIV3(config)
+	watchCollection, watchItem := watchCollectionAndItemPaths(config, config.APIKinds[0])
+
+	if _, ok := doc.Paths.Paths[watchCollection]; !ok {
+		t.Errorf("expected a watch path item at %s", watchCollection)
+	}
+	if _, ok := doc.Paths.Paths[watchItem]; !ok {
+		t.Errorf("expected a watch path item at %s", watchItem)
+	}
+}
@@ -0,0 +1,37 @@
+package framework
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// continueToken is the framework's own opaque pagination cursor for list
+// responses whose items were filtered in-memory (selectors the upstream
+// store can't evaluate itself). It's never inspected by the client, only
+// round-tripped through the `continue` query parameter, the way the
+// apiserver's own continue tokens work.
+type continueToken struct {
+	ResourceVersion string `json:"rv"`
+	Offset          int    `json:"offset"`
+}
+
+func encodeContinueToken(t continueToken) string {
+	raw, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeContinueToken(s string) (continueToken, error) {
+	var t continueToken
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, fmt.Errorf("invalid continue token: %w", err)
+	}
+
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return t, fmt.Errorf("invalid continue token: %w", err)
+	}
+
+	return t, nil
+}
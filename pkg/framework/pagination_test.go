@@ -0,0 +1,33 @@
+package framework
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestContinueTokenRoundTrip(t *testing.T) {
+	want := continueToken{ResourceVersion: "1234", Offset: 42}
+
+	got, err := decodeContinueToken(encodeContinueToken(want))
+	if err != nil {
+		t.Fatalf("decodeContinueToken: %v", err)
+	}
+
+	if got != want {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeContinueTokenInvalid(t *testing.T) {
+	cases := []string{
+		"",
+		"not-base64!!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+	}
+
+	for _, c := range cases {
+		if _, err := decodeContinueToken(c); err == nil {
+			t.Errorf("decodeContinueToken(%q): expected error, got nil", c)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// handlePatch serves PATCH /apis/<group>/<version>/<resource>/<name>,
+// supporting JSON Patch, JSON merge patch, strategic merge patch, and
+// server-side apply, mirroring the patch types the apiserver accepts.
+func (rr *resourceRequest) handlePatch(w http.ResponseWriter, r *http.Request) {
+	s := rr.srv
+	res := rr.res
+
+	newGVR, obj := res.CreateNew()
+	newKind, err := s.KubeClient.RESTMapper().KindFor(newGVR)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to find kind: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.KubeClient.Get(r.Context(), client.ObjectKey{Namespace: rr.namespace, Name: rr.name}, obj); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			http.Error(w, "failed to get resource: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.NotFound(w, r)
+		return
+	}
+
+	patchBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	patchType := types.PatchType(r.Header.Get("Content-Type"))
+
+	var patch client.Patch
+	switch patchType {
+	case types.JSONPatchType, types.MergePatchType, types.StrategicMergePatchType:
+		patch = client.RawPatch(patchType, patchBytes)
+	case types.ApplyPatchType:
+		applied := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(patchBytes, &applied.Object); err != nil {
+			http.Error(w, "failed to decode apply patch: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		obj = applied
+	default:
+		http.Error(w, "unsupported patch type: "+string(patchType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if patchType == types.ApplyPatchType {
+		mutated, err := runAdmission(r.Context(), res.MutatingAdmission, rr.namespace, rr.name, obj)
+		if err != nil {
+			http.Error(w, "admission denied: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		obj = mutated.(client.Object)
+
+		fieldManager := r.URL.Query().Get("fieldManager")
+		if fieldManager == "" {
+			fieldManager = "kubernetes-aggregator-framework"
+		}
+
+		applyOpts := []client.PatchOption{client.FieldOwner(fieldManager)}
+		if isTrue(r.URL.Query().Get("force")) {
+			applyOpts = append(applyOpts, client.ForceOwnership)
+		}
+
+		if err := s.KubeClient.Patch(r.Context(), obj, client.Apply, applyOpts...); err != nil {
+			http.Error(w, "failed to apply resource: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		updated, err := GuaranteedUpdate(r.Context(), s.KubeClient, newGVR,
+			client.ObjectKey{Namespace: rr.namespace, Name: rr.name},
+			func() client.Object { _, empty := res.CreateNew(); return empty },
+			nil,
+			func(current client.Object) (client.Object, error) {
+				// Dry-run the patch to find out what it would actually
+				// produce, so MutatingAdmission sees (and can still
+				// mutate) the patched object rather than the pre-patch
+				// snapshot, then persist whatever admission returns.
+				dryRun, ok := current.DeepCopyObject().(client.Object)
+				if !ok {
+					return nil, fmt.Errorf("object of type %T does not implement client.Object", current)
+				}
+
+				if err := s.KubeClient.Patch(r.Context(), dryRun, patch, client.DryRunAll); err != nil {
+					return nil, err
+				}
+
+				mutated, err := runAdmission(r.Context(), res.MutatingAdmission, rr.namespace, rr.name, dryRun)
+				if err != nil {
+					return nil, err
+				}
+				final := mutated.(client.Object)
+				final.SetResourceVersion(current.GetResourceVersion())
+
+				if err := s.KubeClient.Update(r.Context(), final); err != nil {
+					return nil, err
+				}
+
+				return final, nil
+			})
+		if err != nil {
+			var admissionErr *AdmissionError
+			if errors.As(err, &admissionErr) {
+				http.Error(w, "admission denied: "+admissionErr.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if apierrors.IsConflict(err) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+
+			if apierrors.IsNotFound(err) {
+				http.NotFound(w, r)
+				return
+			}
+
+			http.Error(w, "failed to patch resource: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		obj = updated
+	}
+
+	obj.GetObjectKind().SetGroupVersionKind(newKind)
+
+	var out any = obj
+	if res.PatchCallback != nil {
+		out, err = res.PatchCallback(r.Context(), rr.namespace, rr.name, obj)
+		if err != nil {
+			http.Error(w, "failed to patch resource: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := writeResponse(w, r, s.KubeClient.Scheme(), http.StatusOK, out); err != nil {
+		http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func isTrue(v string) bool {
+	return v == "true" || v == "1"
+}
@@ -0,0 +1,40 @@
+package framework
+
+import (
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// matchesSelectors reports whether obj satisfies both selectors. Either may
+// be nil, meaning "no constraint". Field selectors are evaluated against
+// fieldsFor(res, obj) rather than forwarded upstream, since the upstream
+// store generally can't index fields a Resource makes up: the list and watch
+// handlers both call this instead of trusting an upstream fieldSelector.
+func matchesSelectors(res Resource, obj client.Object, labelSelector labels.Selector, fieldSelector fields.Selector) bool {
+	if labelSelector != nil && !labelSelector.Empty() && !labelSelector.Matches(labels.Set(obj.GetLabels())) {
+		return false
+	}
+
+	if fieldSelector != nil && !fieldSelector.Empty() && !fieldSelector.Matches(fieldsFor(res, obj)) {
+		return false
+	}
+
+	return true
+}
+
+// fieldsFor builds the selectable field set for obj: metadata.name and
+// metadata.namespace, which the apiserver always supports, plus whatever
+// Resource.FieldIndexers declares.
+func fieldsFor(res Resource, obj client.Object) fields.Set {
+	set := fields.Set{
+		"metadata.name":      obj.GetName(),
+		"metadata.namespace": obj.GetNamespace(),
+	}
+
+	for name, extract := range res.FieldIndexers {
+		set[name] = extract(obj)
+	}
+
+	return set
+}
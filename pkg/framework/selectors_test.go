@@ -0,0 +1,76 @@
+package framework
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestObject(namespace, name string, lbls map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetLabels(lbls)
+	return obj
+}
+
+func TestMatchesSelectorsLabel(t *testing.T) {
+	obj := newTestObject("ns", "widget-a", map[string]string{"env": "prod"})
+
+	selector, err := labels.Parse("env=prod")
+	if err != nil {
+		t.Fatalf("labels.Parse: %v", err)
+	}
+
+	if !matchesSelectors(Resource{}, obj, selector, nil) {
+		t.Error("expected label selector to match")
+	}
+
+	selector, err = labels.Parse("env=staging")
+	if err != nil {
+		t.Fatalf("labels.Parse: %v", err)
+	}
+
+	if matchesSelectors(Resource{}, obj, selector, nil) {
+		t.Error("expected label selector not to match")
+	}
+}
+
+func TestMatchesSelectorsField(t *testing.T) {
+	obj := newTestObject("ns", "widget-a", nil)
+
+	res := Resource{
+		FieldIndexers: map[string]FieldIndexerFunc{
+			"spec.size": func(o client.Object) string { return "large" },
+		},
+	}
+
+	selector, err := fields.ParseSelector("metadata.name=widget-a,spec.size=large")
+	if err != nil {
+		t.Fatalf("fields.ParseSelector: %v", err)
+	}
+
+	if !matchesSelectors(res, obj, nil, selector) {
+		t.Error("expected field selector to match")
+	}
+
+	selector, err = fields.ParseSelector("spec.size=small")
+	if err != nil {
+		t.Fatalf("fields.ParseSelector: %v", err)
+	}
+
+	if matchesSelectors(res, obj, nil, selector) {
+		t.Error("expected field selector not to match")
+	}
+}
+
+func TestMatchesSelectorsNilIsNoConstraint(t *testing.T) {
+	obj := newTestObject("ns", "widget-a", nil)
+
+	if !matchesSelectors(Resource{}, obj, nil, nil) {
+		t.Error("expected nil selectors to match everything")
+	}
+}
@@ -0,0 +1,110 @@
+package framework
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// TableConvertor lets a Resource (or an APIKind, as a fallback for all of its
+// Resources) describe how its objects should be rendered when a client
+// negotiates the Table content type kubectl get uses instead of the raw
+// list/object JSON.
+type TableConvertor struct {
+	// TableColumns describes the columns kubectl prints, in the order they
+	// should appear.
+	TableColumns []metav1.TableColumnDefinition
+	// TableRow returns the cell values for a single object, matching
+	// TableColumns by position.
+	TableRow func(obj runtime.Object) []interface{}
+}
+
+// wantsTable reports whether the request's Accept header negotiated the
+// metav1.Table content type, e.g. "application/json;as=Table;v=v1;g=meta.k8s.io".
+func wantsTable(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		_, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		if strings.EqualFold(params["as"], "Table") && strings.EqualFold(params["g"], "meta.k8s.io") && params["v"] == "v1" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tableIncludeObjectPolicy returns the includeObject policy the client asked
+// for via the ?includeObject= query parameter, defaulting to IncludeMetadata
+// the way the apiserver does.
+func tableIncludeObjectPolicy(r *http.Request) metav1.IncludeObjectPolicy {
+	switch metav1.IncludeObjectPolicy(r.URL.Query().Get("includeObject")) {
+	case metav1.IncludeNone:
+		return metav1.IncludeNone
+	case metav1.IncludeObject:
+		return metav1.IncludeObject
+	default:
+		return metav1.IncludeMetadata
+	}
+}
+
+// convertToTable renders objs (already GVK-stamped) into a metav1.Table using
+// convertor, embedding the source object in each row according to include.
+func convertToTable(convertor TableConvertor, include metav1.IncludeObjectPolicy, objs []runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Table",
+			APIVersion: "meta.k8s.io/v1",
+		},
+		ColumnDefinitions: convertor.TableColumns,
+		Rows:              make([]metav1.TableRow, 0, len(objs)),
+	}
+
+	for _, obj := range objs {
+		row := metav1.TableRow{
+			Cells: convertor.TableRow(obj),
+		}
+
+		switch include {
+		case metav1.IncludeObject:
+			raw, err := json.Marshal(obj)
+			if err != nil {
+				return nil, err
+			}
+			row.Object = runtime.RawExtension{Raw: raw}
+		case metav1.IncludeMetadata:
+			raw, err := json.Marshal(obj)
+			if err != nil {
+				return nil, err
+			}
+
+			// Trim to TypeMeta+ObjectMeta only, per IncludeObjectPolicy's
+			// contract: IncludeMetadata must not leak spec/status.
+			var partial metav1.PartialObjectMetadata
+			if err := json.Unmarshal(raw, &partial); err != nil {
+				return nil, err
+			}
+
+			raw, err = json.Marshal(partial)
+			if err != nil {
+				return nil, err
+			}
+			row.Object = runtime.RawExtension{Raw: raw}
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table, nil
+}
@@ -0,0 +1,84 @@
+package framework
+
+import (
+	"encoding/json"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newTableTestObject() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("example.com/v1")
+	obj.SetKind("Widget")
+	obj.SetName("widget-a")
+	obj.SetNamespace("ns")
+	obj.SetLabels(map[string]string{"env": "prod"})
+	_ = unstructured.SetNestedField(obj.Object, "big", "spec", "size")
+	return obj
+}
+
+func testConvertor() TableConvertor {
+	return TableConvertor{
+		TableColumns: []metav1.TableColumnDefinition{{Name: "Name", Type: "string"}},
+		TableRow: func(obj runtime.Object) []interface{} {
+			u := obj.(*unstructured.Unstructured)
+			return []interface{}{u.GetName()}
+		},
+	}
+}
+
+func TestConvertToTableIncludeNone(t *testing.T) {
+	table, err := convertToTable(testConvertor(), metav1.IncludeNone, []runtime.Object{newTableTestObject()})
+	if err != nil {
+		t.Fatalf("convertToTable: %v", err)
+	}
+
+	if len(table.Rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(table.Rows))
+	}
+	if table.Rows[0].Object.Raw != nil {
+		t.Error("expected no embedded object for IncludeNone")
+	}
+}
+
+func TestConvertToTableIncludeMetadata(t *testing.T) {
+	table, err := convertToTable(testConvertor(), metav1.IncludeMetadata, []runtime.Object{newTableTestObject()})
+	if err != nil {
+		t.Fatalf("convertToTable: %v", err)
+	}
+
+	var partial metav1.PartialObjectMetadata
+	if err := json.Unmarshal(table.Rows[0].Object.Raw, &partial); err != nil {
+		t.Fatalf("unmarshal embedded object: %v", err)
+	}
+
+	if partial.Name != "widget-a" || partial.Namespace != "ns" {
+		t.Errorf("expected metadata to survive trimming, got %+v", partial.ObjectMeta)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(table.Rows[0].Object.Raw, &raw); err != nil {
+		t.Fatalf("unmarshal raw: %v", err)
+	}
+	if _, ok := raw["spec"]; ok {
+		t.Error("expected spec to be trimmed from IncludeMetadata object")
+	}
+}
+
+func TestConvertToTableIncludeObject(t *testing.T) {
+	table, err := convertToTable(testConvertor(), metav1.IncludeObject, []runtime.Object{newTableTestObject()})
+	if err != nil {
+		t.Fatalf("convertToTable: %v", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(table.Rows[0].Object.Raw, &raw); err != nil {
+		t.Fatalf("unmarshal raw: %v", err)
+	}
+	if _, ok := raw["spec"]; !ok {
+		t.Error("expected spec to survive for IncludeObject")
+	}
+}
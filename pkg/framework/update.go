@@ -0,0 +1,91 @@
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// handleUpdate serves PUT /apis/<group>/<version>/<resource>/<name>, replacing
+// an existing object wholesale.
+func (rr *resourceRequest) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	s := rr.srv
+	res := rr.res
+
+	newGVR, obj := res.CreateNew()
+	newKind, err := s.KubeClient.RESTMapper().KindFor(newGVR)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to find kind: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(obj); err != nil {
+		http.Error(w, "failed to decode request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	obj.SetNamespace(rr.namespace)
+	obj.SetName(rr.name)
+
+	mutated, err := runAdmission(r.Context(), res.MutatingAdmission, rr.namespace, rr.name, obj)
+	if err != nil {
+		http.Error(w, "admission denied: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	obj = mutated.(client.Object)
+
+	var preconditions *Preconditions
+	if rv := obj.GetResourceVersion(); rv != "" {
+		preconditions = &Preconditions{ResourceVersion: &rv}
+	}
+
+	updated, err := GuaranteedUpdate(r.Context(), s.KubeClient, newGVR,
+		client.ObjectKey{Namespace: rr.namespace, Name: rr.name},
+		func() client.Object { _, empty := res.CreateNew(); return empty },
+		preconditions,
+		func(current client.Object) (client.Object, error) {
+			// Carry current's resourceVersion forward on every attempt - obj
+			// was decoded once before the retry loop started, so without this
+			// a retry after a real conflict just resends the same stale
+			// resourceVersion and conflicts again, burning every attempt.
+			obj.SetResourceVersion(current.GetResourceVersion())
+
+			if err := s.KubeClient.Update(r.Context(), obj); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		})
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		if apierrors.IsNotFound(err) {
+			http.NotFound(w, r)
+			return
+		}
+
+		http.Error(w, "failed to update resource: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	obj = updated
+
+	obj.GetObjectKind().SetGroupVersionKind(newKind)
+
+	var out any = obj
+	if res.UpdateCallback != nil {
+		out, err = res.UpdateCallback(r.Context(), rr.namespace, rr.name, obj)
+		if err != nil {
+			http.Error(w, "failed to update resource: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := writeResponse(w, r, s.KubeClient.Scheme(), http.StatusOK, out); err != nil {
+		http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,105 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// raceyUpdateClient reproduces the race handleUpdate's tryUpdate has to
+// survive: the object conflicts on its first Update because a concurrent
+// writer bumped the stored resourceVersion between our Get and our Update.
+type raceyUpdateClient struct {
+	client.Client
+	serverRV       string
+	updateAttempts int
+}
+
+func (f *raceyUpdateClient) Get(_ context.Context, _ client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	obj.(*unstructured.Unstructured).SetResourceVersion(f.serverRV)
+	return nil
+}
+
+func (f *raceyUpdateClient) Update(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	f.updateAttempts++
+	if f.updateAttempts == 1 {
+		// A concurrent writer lands between our Get and our Update.
+		f.serverRV = "6"
+	}
+
+	if obj.GetResourceVersion() != f.serverRV {
+		return apierrors.NewConflict(testGVR.GroupResource(), "widget-a", errors.New("stale resourceVersion"))
+	}
+
+	f.serverRV = "7"
+	return nil
+}
+
+// TestGuaranteedUpdateCarriesCurrentResourceVersionForward exercises
+// handleUpdate's tryUpdate shape directly: obj is decoded once before the
+// retry loop starts, so tryUpdate must refresh obj's resourceVersion from
+// current on every attempt, or a real conflict just gets resent verbatim and
+// fails the same way every retry.
+func TestGuaranteedUpdateCarriesCurrentResourceVersionForward(t *testing.T) {
+	fc := &raceyUpdateClient{serverRV: "5"}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetName("widget-a")
+	obj.SetResourceVersion("5")
+
+	tryUpdate := func(current client.Object) (client.Object, error) {
+		obj.SetResourceVersion(current.GetResourceVersion())
+		if err := fc.Update(context.Background(), obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+
+	result, err := GuaranteedUpdate(context.Background(), fc, testGVR,
+		client.ObjectKey{Name: "widget-a"},
+		func() client.Object { return &unstructured.Unstructured{} },
+		nil, tryUpdate)
+	if err != nil {
+		t.Fatalf("GuaranteedUpdate: %v", err)
+	}
+	if result.GetResourceVersion() != "6" {
+		t.Fatalf("expected the retry to persist with resourceVersion 6, got %q", result.GetResourceVersion())
+	}
+	if fc.updateAttempts != 2 {
+		t.Fatalf("expected exactly one retry (2 Update attempts), got %d", fc.updateAttempts)
+	}
+}
+
+// TestGuaranteedUpdateWithoutResourceVersionRefreshNeverSucceeds guards
+// against regressing to the old, broken tryUpdate shape: if obj's
+// resourceVersion is never refreshed from current, the same conflict repeats
+// on every retry until GuaranteedUpdate gives up.
+func TestGuaranteedUpdateWithoutResourceVersionRefreshNeverSucceeds(t *testing.T) {
+	fc := &raceyUpdateClient{serverRV: "5"}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetName("widget-a")
+	obj.SetResourceVersion("5")
+
+	tryUpdate := func(current client.Object) (client.Object, error) {
+		if err := fc.Update(context.Background(), obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+
+	_, err := GuaranteedUpdate(context.Background(), fc, testGVR,
+		client.ObjectKey{Name: "widget-a"},
+		func() client.Object { return &unstructured.Unstructured{} },
+		nil, tryUpdate)
+	if err == nil {
+		t.Fatal("expected GuaranteedUpdate to exhaust its retries when obj's resourceVersion is never refreshed")
+	}
+	if fc.updateAttempts != maxGuaranteedUpdateRetries {
+		t.Fatalf("expected all %d attempts to be burned, got %d", maxGuaranteedUpdateRetries, fc.updateAttempts)
+	}
+}
@@ -0,0 +1,251 @@
+package framework
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// handleWatch serves GET ?watch=true, streaming watch events for a single
+// resource (rr.name != "") or a whole collection, framed and encoded
+// according to the client's negotiated content type.
+func (rr *resourceRequest) handleWatch(w http.ResponseWriter, r *http.Request) {
+	s := rr.srv
+	res := rr.res
+
+	newGVR, emptyObj := res.CreateNew()
+	newKind, err := s.KubeClient.RESTMapper().KindFor(newGVR)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to find kind: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported by server", http.StatusInternalServerError)
+		return
+	}
+
+	scheme := s.KubeClient.Scheme()
+
+	// WatchCallback may return a type the scheme knows nothing about, so a
+	// watch using one can only stream plain JSON: the embedded object has to
+	// be marshaled generically rather than through the negotiated serializer.
+	info, mediaType := negotiateSerializerInfo(scheme, r, emptyObj)
+	if res.WatchCallback != nil {
+		info, _ = runtime.SerializerInfoForMediaType(serializer.NewCodecFactory(scheme).SupportedMediaTypes(), runtime.ContentTypeJSON)
+		mediaType = runtime.ContentTypeJSON
+	}
+
+	if info.StreamSerializer == nil {
+		http.Error(w, "no stream serializer for negotiated media type "+mediaType, http.StatusNotAcceptable)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType+";stream=watch")
+	frameWriter := info.StreamSerializer.Framer.NewFrameWriter(w)
+	enc := streaming.NewEncoder(frameWriter, info.StreamSerializer.Serializer)
+
+	allowBookmarks := strings.EqualFold(r.URL.Query().Get("allowWatchBookmarks"), "true")
+
+	rv := r.URL.Query().Get("resourceVersion")
+
+	var rvm = metav1.ResourceVersionMatchNotOlderThan
+	switch r.URL.Query().Get("resourceVersionMatch") {
+	case string(metav1.ResourceVersionMatchExact):
+		rvm = metav1.ResourceVersionMatchExact
+	case string(metav1.ResourceVersionMatchNotOlderThan):
+		rvm = metav1.ResourceVersionMatchNotOlderThan
+	}
+
+	sendInitial := rv == "" || rv == "0"
+	if sip := r.URL.Query().Get("sendInitialEvents"); sip != "" {
+		sendInitial = strings.EqualFold(sip, "true")
+	}
+
+	if sendInitial && rvm != metav1.ResourceVersionMatchNotOlderThan {
+		http.Error(w, "sendInitialEvents requires resourceVersionMatch=NotOlderThan", http.StatusBadRequest)
+		return
+	}
+
+	timeoutSec := int64(60)
+	if rawTimeoutSec := r.URL.Query().Get("timeoutSeconds"); rawTimeoutSec != "" {
+		if v, err := strconv.ParseInt(rawTimeoutSec, 10, 64); err == nil && v > 0 {
+			timeoutSec = v
+		}
+	}
+
+	// Only metadata.name (when this watch is scoped to a single object) is
+	// forwarded upstream as a fieldSelector; see matchesSelectors for why the
+	// rest of fieldSelector is evaluated in the framework instead. labelSelector,
+	// by contrast, is forwarded upstream as a prefilter the same way
+	// handleList does, since the upstream store can evaluate it directly -
+	// matchesSelectors below is then a cheap redundant check, not doing all
+	// the filtering work itself.
+	upstreamFieldSelector := ""
+	if rr.name != "" {
+		upstreamFieldSelector = "metadata.name=" + rr.name
+	}
+
+	rawLabelSelector := r.URL.Query().Get("labelSelector")
+
+	var labelSelector labels.Selector
+	if rawLabelSelector != "" {
+		labelSelector, err = labels.Parse(rawLabelSelector)
+		if err != nil {
+			http.Error(w, "failed to parse labelSelector: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var fieldSelector fields.Selector
+	if fs := r.URL.Query().Get("fieldSelector"); fs != "" {
+		fieldSelector, err = fields.ParseSelector(fs)
+		if err != nil {
+			http.Error(w, "failed to parse fieldSelector: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	listOpts := metav1.ListOptions{
+		ResourceVersion:      rv,
+		ResourceVersionMatch: rvm,
+		TimeoutSeconds:       &timeoutSec,
+		SendInitialEvents:    &sendInitial,
+		Watch:                true,
+		AllowWatchBookmarks:  allowBookmarks,
+		LabelSelector:        rawLabelSelector,
+		FieldSelector:        upstreamFieldSelector,
+	}
+
+	watcher, err := s.DynamicKubeCluient.Resource(newGVR).Namespace(rr.namespace).Watch(r.Context(), listOpts)
+	if err != nil {
+		var se *apierrors.StatusError
+		if errors.As(err, &se) && se.ErrStatus.Code == http.StatusGone {
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+
+		http.Error(w, "failed to initialize watcher: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			watcher.Stop()
+			return
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+
+			if event.Type == watch.Error {
+				if status, ok := event.Object.(*metav1.Status); ok {
+					http.Error(w, fmt.Sprintf("watch error: %s", status.Message), int(status.Code))
+					return
+				}
+
+				http.Error(w, "watch error: unknown", http.StatusInternalServerError)
+				return
+			} else if event.Type == watch.Bookmark {
+				if !allowBookmarks {
+					continue
+				}
+
+				if err := rr.encodeWatchEvent(enc, info.StreamSerializer.Serializer, mediaType, event.Type, event.Object); err != nil {
+					http.Error(w, "failed to send bookmark: "+err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				flusher.Flush()
+				continue
+			} else if event.Object == nil {
+				continue
+			}
+
+			event.Object.GetObjectKind().SetGroupVersionKind(newKind)
+
+			unstructuredObj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				http.Error(w, "failed to cast to unstructured", http.StatusInternalServerError)
+				return
+			}
+
+			if !matchesSelectors(res, unstructuredObj, labelSelector, fieldSelector) {
+				continue
+			}
+
+			var out any = unstructuredObj
+			if res.WatchCallback != nil {
+				out, err = res.WatchCallback(r.Context(), rr.namespace, rr.name, unstructuredObj)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("watch error: %s", err.Error()), http.StatusInternalServerError)
+					return
+				}
+			}
+
+			if rr.tableConvertor.TableRow != nil && wantsTable(r) {
+				if obj, ok := out.(runtime.Object); ok {
+					table, err := convertToTable(rr.tableConvertor, tableIncludeObjectPolicy(r), []runtime.Object{obj})
+					if err != nil {
+						http.Error(w, fmt.Sprintf("failed to convert to table: %s", err.Error()), http.StatusInternalServerError)
+						return
+					}
+					out = table
+				}
+			}
+
+			if err := rr.encodeWatchEvent(enc, info.StreamSerializer.Serializer, mediaType, event.Type, out); err != nil {
+				http.Error(w, "failed to encode watch response: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// encodeWatchEvent embeds obj into a metav1.WatchEvent and sends it through
+// enc, the way the apiserver streams watch events for a negotiated JSON or
+// protobuf stream. YAML has no stream serializer, so handleWatch answers a
+// YAML watch with 406 before getting here. obj that isn't a runtime.Object
+// (e.g. a custom type returned by a WatchCallback) can only be embedded
+// verbatim over JSON.
+func (rr *resourceRequest) encodeWatchEvent(enc streaming.Encoder, embedded runtime.Serializer, mediaType string, eventType watch.EventType, obj any) error {
+	var raw []byte
+
+	if robj, ok := obj.(runtime.Object); ok {
+		encoded, err := runtime.Encode(embedded, robj)
+		if err != nil {
+			return err
+		}
+		raw = encoded
+	} else if mediaType == runtime.ContentTypeJSON {
+		encoded, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		raw = encoded
+	} else {
+		return fmt.Errorf("watch result of type %T cannot be encoded as %s", obj, mediaType)
+	}
+
+	return enc.Encode(&metav1.WatchEvent{
+		Type:   string(eventType),
+		Object: runtime.RawExtension{Raw: raw},
+	})
+}